@@ -15,29 +15,74 @@
 package multi
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 	"os/exec"
-	"strconv"
+	"reflect"
 	"strings"
 	"text/tabwriter"
 	"time"
 
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
 
 	"github.com/spf13/cobra"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	// register the standard cloud auth-provider plugins (gcp, oidc, azure, openstack)
+	// so BuildClientConfig can load contexts that rely on them.
+	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/tools/clientcmd/api"
 )
 
-func BuildClientConfig(kubeconfig, context string) clientcmd.ClientConfig {
+const (
+	istioSystemNamespace  = "istio-system"
+	citadelDeploymentName = "istio-citadel"
+	caSecretName          = "istio-ca-secret"
+	cacertsSecretName     = "cacerts"
+	defaultAccountSecret  = "istio.default"
+	rolloutPollInterval   = 2 * time.Second
+	rolloutTimeout        = 5 * time.Minute
+
+	remoteSecretPrefix      = "istio-mc-"
+	multiClusterLabel       = "istio/multiCluster"
+	preJoinBackupSuffix     = "-pre-join-backup"
+	pilotServiceAccountName = "istio-pilot-service-account"
+
+	authProviderExec  = "exec"
+	authProviderGCP   = "gcp"
+	authProviderOIDC  = "oidc"
+	authProviderAzure = "azure"
+
+	// minCredentialLifetime is the shortest remaining lifetime preflightAuthExpiry
+	// will accept for an exec-plugin-issued credential before refusing to proceed.
+	minCredentialLifetime = 24 * time.Hour
+)
+
+// AuthOverrides selects a non-default kubeconfig auth provider for a cluster, for
+// contexts whose stored AuthInfo doesn't already declare one.
+type AuthOverrides struct {
+	// Provider is one of authProviderExec, authProviderGCP, authProviderOIDC,
+	// authProviderAzure, or "" to use whatever the kubeconfig already specifies.
+	// BuildClientConfig applies only gcp, oidc and azure; authProviderExec is accepted
+	// but leaves the loaded AuthInfo unchanged, so an exec plugin is used only when the
+	// kubeconfig's own user entry declares one.
+	Provider string
+	// Config carries the AuthProviderConfig.Config entries the chosen Provider needs,
+	// e.g. idp-issuer-url/client-id/refresh-token for oidc, or environment/tenant-id
+	// for azure. Unused for exec, and optional for gcp (which falls back to Application
+	// Default Credentials when empty).
+	Config map[string]string
+}
+
+func BuildClientConfig(kubeconfig, context string, auth AuthOverrides) clientcmd.ClientConfig {
 	if kubeconfig != "" {
 		info, err := os.Stat(kubeconfig)
 		if err != nil || info.Size() == 0 {
@@ -60,14 +105,99 @@ func BuildClientConfig(kubeconfig, context string) clientcmd.ClientConfig {
 		CurrentContext:  context,
 	}
 
+	// ConfigOverrides.AuthInfo is merged onto the loaded AuthInfo with
+	// mergo.MergeWithOverwrite, which replaces the whole AuthProviderConfig struct
+	// (including its Config map) rather than just the Name field. Only apply the
+	// override when the context doesn't already have a provider configured, so we
+	// never clobber an existing provider's Config (idp-issuer-url, client-id, etc),
+	// and always carry auth.Config through so oidc/azure have what they need to
+	// authenticate from scratch.
+	switch auth.Provider {
+	case authProviderGCP, authProviderOIDC, authProviderAzure:
+		if !contextHasAuthProvider(loadingRules, context) {
+			configOverrides.AuthInfo.AuthProvider = &api.AuthProviderConfig{Name: auth.Provider, Config: auth.Config}
+		}
+	}
+
 	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
 }
 
+// contextHasAuthProvider reports whether context's AuthInfo, as currently stored in
+// the kubeconfig(s) loadingRules resolves, already declares an auth provider.
+func contextHasAuthProvider(loadingRules *clientcmd.ClientConfigLoadingRules, context string) bool {
+	config, err := loadingRules.Load()
+	if err != nil {
+		return false
+	}
+
+	kubeContext, ok := config.Contexts[context]
+	if !ok {
+		return false
+	}
+
+	authInfo, ok := config.AuthInfos[kubeContext.AuthInfo]
+	return ok && authInfo.AuthProvider != nil
+}
+
+// execCredentialStatus mirrors the subset of the client.authentication.k8s.io
+// ExecCredential response that preflightAuthExpiry needs: when the token the plugin
+// minted expires.
+type execCredentialStatus struct {
+	Status struct {
+		ExpirationTimestamp *time.Time `json:"expirationTimestamp"`
+	} `json:"status"`
+}
+
+// preflightAuthExpiry resolves context's AuthInfo and, if it's backed by an exec
+// credential plugin, runs the plugin and fails fast if the credential it returns
+// expires in under minCredentialLifetime. This surfaces a cluster whose exec-based
+// login is about to lapse before a long join (or join --watch) run fails partway
+// through. Only `multi join` runs this check.
+func preflightAuthExpiry(kubeconfig, context string) error {
+	config, err := BuildClientConfig(kubeconfig, context, AuthOverrides{}).RawConfig()
+	if err != nil {
+		return fmt.Errorf("load kubeconfig for %q: %v", context, err)
+	}
+
+	kubeContext, ok := config.Contexts[context]
+	if !ok {
+		return fmt.Errorf("context %q not found", context)
+	}
+	authInfo, ok := config.AuthInfos[kubeContext.AuthInfo]
+	if !ok || authInfo.Exec == nil {
+		return nil
+	}
+
+	execCmd := exec.Command(authInfo.Exec.Command, authInfo.Exec.Args...)
+	execCmd.Env = os.Environ()
+	for _, e := range authInfo.Exec.Env {
+		execCmd.Env = append(execCmd.Env, fmt.Sprintf("%v=%v", e.Name, e.Value))
+	}
+
+	out, err := execCmd.Output()
+	if err != nil {
+		return fmt.Errorf("run exec credential plugin for %q: %v", context, err)
+	}
+
+	var cred execCredentialStatus
+	if err := json.Unmarshal(out, &cred); err != nil {
+		return fmt.Errorf("parse exec credential plugin output for %q: %v", context, err)
+	}
+
+	if cred.Status.ExpirationTimestamp != nil && time.Until(*cred.Status.ExpirationTimestamp) < minCredentialLifetime {
+		return fmt.Errorf("credential for %q expires at %v, in under %v", context, cred.Status.ExpirationTimestamp, minCredentialLifetime)
+	}
+
+	return nil
+}
+
 type Args struct {
-	kubeconfig string
-	context    string
-	clusters   []string
-	namespace  string
+	kubeconfig    string
+	context       string
+	clusters      []string
+	namespace     string
+	watch         bool
+	authOverrides map[string]AuthOverrides
 
 	config clientcmd.ClientConfig
 }
@@ -123,6 +253,444 @@ users:
     token: ${TOKEN}
 `
 
+// listNamespaces returns the names of all namespaces in the cluster reachable via cs.
+func listNamespaces(cs kubernetes.Interface) ([]string, error) {
+	list, err := cs.CoreV1().Namespaces().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(list.Items))
+	for _, ns := range list.Items {
+		names = append(names, ns.Name)
+	}
+	return names, nil
+}
+
+// scaleDeployment sets deployment/name in namespace to replicas via the scale subresource
+// and blocks until the deployment reports that many ready replicas, or rolloutTimeout elapses.
+func scaleDeployment(cs kubernetes.Interface, namespace, name string, replicas int32) error {
+	deployments := cs.AppsV1().Deployments(namespace)
+
+	scale, err := deployments.GetScale(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get scale for %v/%v: %v", namespace, name, err)
+	}
+
+	scale.Spec.Replicas = replicas
+	if _, err := deployments.UpdateScale(name, scale); err != nil {
+		return fmt.Errorf("scale %v/%v to %v: %v", namespace, name, replicas, err)
+	}
+
+	deadline := time.Now().Add(rolloutTimeout)
+	for {
+		dep, err := deployments.Get(name, metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("get deployment %v/%v: %v", namespace, name, err)
+		}
+		if dep.Status.Replicas == replicas && dep.Status.ReadyReplicas == replicas {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out waiting for %v/%v to reach %v replicas", namespace, name, replicas)
+		}
+		time.Sleep(rolloutPollInterval)
+	}
+}
+
+// disableCitadel scales istio-citadel to zero replicas so that it stops serving the
+// current root CA while the secrets backing it are swapped out from under it.
+func disableCitadel(cs kubernetes.Interface, namespace string) error {
+	return scaleDeployment(cs, namespace, citadelDeploymentName, 0)
+}
+
+// backupPreJoinCASecrets saves a copy of the destination cluster's own CA secrets,
+// if present, before they are overwritten by syncRootCA, so `multi unjoin` can restore
+// them later. It is a no-op if a backup already exists, so re-joins don't clobber it.
+func backupPreJoinCASecrets(dstCS kubernetes.Interface, namespace string) error {
+	for _, secretName := range []string{caSecretName, cacertsSecretName} {
+		backupName := secretName + preJoinBackupSuffix
+
+		if _, err := dstCS.CoreV1().Secrets(namespace).Get(backupName, metav1.GetOptions{}); err == nil {
+			continue
+		} else if !errors.IsNotFound(err) {
+			return fmt.Errorf("get backup secret %v/%v: %v", namespace, backupName, err)
+		}
+
+		existing, err := dstCS.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("get secret %v/%v: %v", namespace, secretName, err)
+		}
+
+		backup := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      backupName,
+				Namespace: namespace,
+			},
+			Data: existing.Data,
+			Type: existing.Type,
+		}
+		if _, err := dstCS.CoreV1().Secrets(namespace).Create(backup); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("create backup secret %v/%v: %v", namespace, backupName, err)
+		}
+	}
+
+	return nil
+}
+
+// caInSync reports whether dst's CA secrets already match src's, so callers can skip
+// the disableCitadel/syncRootCA/restartDataPlane sequence on a cluster that's already
+// joined - the same drift check reconcileRemoteSecret uses for remote secrets.
+func caInSync(srcCS, dstCS kubernetes.Interface, namespace string) (bool, error) {
+	for _, secretName := range []string{caSecretName, cacertsSecretName} {
+		srcSecret, err := srcCS.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return false, fmt.Errorf("get secret %v/%v on source cluster: %v", namespace, secretName, err)
+		}
+
+		dstSecret, err := dstCS.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, fmt.Errorf("get secret %v/%v on destination cluster: %v", namespace, secretName, err)
+		}
+
+		if !reflect.DeepEqual(srcSecret.Data, dstSecret.Data) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// syncRootCA removes the destination cluster's citadel-managed secrets, copies the
+// source cluster's root CA secrets over in their place, and scales citadel back up so
+// it picks up the new root. It assumes disableCitadel has already been called for dst.
+func syncRootCA(srcCS, dstCS kubernetes.Interface, namespace string) error {
+	if err := backupPreJoinCASecrets(dstCS, namespace); err != nil {
+		return fmt.Errorf("back up pre-join CA secrets: %v", err)
+	}
+
+	// remove existing self-signed and externally provided certs
+	if err := dstCS.CoreV1().Secrets(namespace).Delete(caSecretName, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+		log.Printf("delete secret %v/%v: %v", namespace, caSecretName, err)
+	}
+
+	namespaces, err := listNamespaces(dstCS)
+	if err != nil {
+		return fmt.Errorf("list namespaces: %v", err)
+	}
+
+	// TODO - this should delete *all* Istio secrets, not just the default service account's
+	for _, ns := range namespaces {
+		if err := dstCS.CoreV1().Secrets(ns).Delete(defaultAccountSecret, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			log.Printf("delete secret %v/%v: %v", ns, defaultAccountSecret, err)
+		}
+	}
+
+	// source cluster may have a self-signed or a plugged cert; copy whichever exists
+	for _, secretName := range []string{caSecretName, cacertsSecretName} {
+		srcSecret, err := srcCS.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("get secret %v/%v on source cluster: %v", namespace, secretName, err)
+		}
+
+		dstSecret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      srcSecret.Name,
+				Namespace: namespace,
+			},
+			Data: srcSecret.Data,
+			Type: srcSecret.Type,
+		}
+
+		if _, err := dstCS.CoreV1().Secrets(namespace).Create(dstSecret); errors.IsAlreadyExists(err) {
+			if _, err := dstCS.CoreV1().Secrets(namespace).Update(dstSecret); err != nil {
+				return fmt.Errorf("update secret %v/%v on destination cluster: %v", namespace, secretName, err)
+			}
+		} else if err != nil {
+			return fmt.Errorf("create secret %v/%v on destination cluster: %v", namespace, secretName, err)
+		}
+	}
+
+	return scaleDeployment(dstCS, namespace, citadelDeploymentName, 1)
+}
+
+// deploymentRolledOut reports whether dep's observed status reflects its latest spec,
+// mirroring the check `kubectl rollout status` performs.
+func deploymentRolledOut(dep *appsv1.Deployment) bool {
+	if dep.Generation > dep.Status.ObservedGeneration {
+		return false
+	}
+	replicas := int32(1)
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+	return dep.Status.UpdatedReplicas >= replicas &&
+		dep.Status.Replicas == dep.Status.UpdatedReplicas &&
+		dep.Status.AvailableReplicas >= replicas
+}
+
+// waitForRollout blocks until dep's deployment in namespace has rolled out, watching its
+// Status rather than polling, or returns an error after rolloutTimeout.
+func waitForRollout(cs kubernetes.Interface, namespace, name string) error {
+	dep, err := cs.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get deployment %v/%v: %v", namespace, name, err)
+	}
+	if deploymentRolledOut(dep) {
+		return nil
+	}
+
+	w, err := cs.AppsV1().Deployments(namespace).Watch(metav1.ListOptions{
+		FieldSelector:   fmt.Sprintf("metadata.name=%v", name),
+		ResourceVersion: dep.ResourceVersion,
+	})
+	if err != nil {
+		return fmt.Errorf("watch deployment %v/%v: %v", namespace, name, err)
+	}
+	defer w.Stop()
+
+	timeout := time.After(rolloutTimeout)
+	for {
+		select {
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return fmt.Errorf("watch on %v/%v closed before rollout completed", namespace, name)
+			}
+			if event.Type != watch.Added && event.Type != watch.Modified {
+				continue
+			}
+			dep, ok := event.Object.(*appsv1.Deployment)
+			if !ok {
+				continue
+			}
+			if deploymentRolledOut(dep) {
+				return nil
+			}
+		case <-timeout:
+			return fmt.Errorf("timed out waiting for %v/%v to roll out", namespace, name)
+		}
+	}
+}
+
+// restartDataPlane annotates every deployment across namespaces, other than those in
+// kube-system and kube-public, with the current time, forcing pods to be recreated and
+// re-injected, and waits for each to roll out.
+func restartDataPlane(cs kubernetes.Interface, namespaces []string) error {
+	patch := []byte(fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"date":"%v"}}}}}`,
+		time.Now().UTC().Format(time.RFC3339)))
+
+	for _, ns := range namespaces {
+		switch ns {
+		case "kube-system", "kube-public":
+			continue
+		}
+
+		deployments, err := cs.AppsV1().Deployments(ns).List(metav1.ListOptions{})
+		if err != nil {
+			return fmt.Errorf("list deployments in %v: %v", ns, err)
+		}
+
+		for _, dep := range deployments.Items {
+			if _, err := cs.AppsV1().Deployments(ns).Patch(dep.Name, types.StrategicMergePatchType, patch); err != nil {
+				return fmt.Errorf("patch deployment %v/%v: %v", ns, dep.Name, err)
+			}
+			if err := waitForRollout(cs, ns, dep.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildRemoteSecret builds the istio-mc-<src> secret that lets workloads on any other
+// cluster reach src's control plane: a kubeconfig embedding src's own API server address
+// and its pilot service account token.
+func buildRemoteSecret(config *api.Config, srcKube kubernetes.Interface, src, namespace string) (*v1.Secret, error) {
+	clusterName := config.Contexts[src].Cluster
+	server := config.Clusters[clusterName].Server
+
+	serviceAccount, err := srcKube.CoreV1().ServiceAccounts(namespace).Get(pilotServiceAccountName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get service account %v/%v: %v", namespace, pilotServiceAccountName, err)
+	}
+	if len(serviceAccount.Secrets) != 1 {
+		return nil, fmt.Errorf("service account %v/%v has %v secrets, expected 1", namespace, pilotServiceAccountName, len(serviceAccount.Secrets))
+	}
+	secretName := serviceAccount.Secrets[0].Name
+
+	pilotSecret, err := srcKube.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get secret %v/%v: %v", namespace, secretName, err)
+	}
+	caData, ok := pilotSecret.Data["ca.crt"]
+	if !ok {
+		return nil, fmt.Errorf("%v/%v is missing ca.crt", namespace, secretName)
+	}
+	token, ok := pilotSecret.Data["token"]
+	if !ok {
+		return nil, fmt.Errorf("%v/%v is missing token", namespace, secretName)
+	}
+
+	sc := api.NewConfig()
+	sc.Kind = "Config"
+	sc.APIVersion = "v1"
+	sc.Clusters[clusterName] = &api.Cluster{
+		CertificateAuthorityData: caData,
+		Server:                   server,
+	}
+	sc.Contexts[clusterName] = &api.Context{
+		Cluster:  clusterName,
+		AuthInfo: clusterName,
+	}
+	sc.CurrentContext = clusterName
+	sc.AuthInfos[clusterName] = &api.AuthInfo{
+		Token: string(token),
+	}
+
+	kubeconfig, err := clientcmd.Write(*sc)
+	if err != nil {
+		return nil, fmt.Errorf("serialize kubeconfig for %v: %v", src, err)
+	}
+
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      remoteSecretPrefix + src,
+			Namespace: namespace,
+			Labels: map[string]string{
+				multiClusterLabel: "true",
+			},
+		},
+		Data: map[string][]byte{
+			clusterName: kubeconfig,
+		},
+	}, nil
+}
+
+// reconcileRemoteSecret creates desired on dst if it's absent, or updates it if the live
+// secret's data - and so its embedded kubeconfig, including the pilot SA token - has
+// drifted, e.g. after token rotation or a changed API server URL. An up-to-date secret
+// is left untouched, which is what makes re-joins and --watch idempotent.
+func reconcileRemoteSecret(dstKube kubernetes.Interface, namespace string, desired *v1.Secret) error {
+	secrets := dstKube.CoreV1().Secrets(namespace)
+
+	existing, err := secrets.Get(desired.Name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		if _, err := secrets.Create(desired); err != nil {
+			return fmt.Errorf("create secret %v/%v: %v", namespace, desired.Name, err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("get secret %v/%v: %v", namespace, desired.Name, err)
+	}
+
+	if reflect.DeepEqual(existing.Data, desired.Data) {
+		return nil
+	}
+
+	desired.ResourceVersion = existing.ResourceVersion
+	if _, err := secrets.Update(desired); err != nil {
+		return fmt.Errorf("update secret %v/%v: %v", namespace, desired.Name, err)
+	}
+	return nil
+}
+
+// joinClusters builds the full len(clusters) x (len(clusters)-1) remote-secret matrix,
+// reconciling every cluster's copy of every other cluster's pilot SA kubeconfig against
+// live state so re-joins converge instead of just blindly overwriting.
+func joinClusters(cmd *cobra.Command, args *Args, config *api.Config, csm map[string]kubernetes.Interface) error {
+	for _, dst := range args.clusters {
+		for _, src := range args.clusters {
+			if src == dst {
+				continue
+			}
+
+			desired, err := buildRemoteSecret(config, csm[src], src, istioSystemNamespace)
+			if err != nil {
+				return fmt.Errorf("build remote secret for %v: %v", src, err)
+			}
+
+			if err := reconcileRemoteSecret(csm[dst], istioSystemNamespace, desired); err != nil {
+				return fmt.Errorf("reconcile remote secret for %v on %v: %v", src, dst, err)
+			}
+			cmd.Printf("reconciled remote secret for %v on %v\n", src, dst)
+		}
+	}
+
+	return nil
+}
+
+// watchClusterTokenRotation watches src's pilot service account secret and re-reconciles
+// every other cluster's copy of src's remote secret whenever the embedded token changes.
+// It blocks until the watch fails or is closed.
+func watchClusterTokenRotation(cmd *cobra.Command, args *Args, config *api.Config, csm map[string]kubernetes.Interface, src string) error {
+	srcKube := csm[src]
+
+	serviceAccount, err := srcKube.CoreV1().ServiceAccounts(istioSystemNamespace).Get(pilotServiceAccountName, metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("get service account %v/%v on %v: %v", istioSystemNamespace, pilotServiceAccountName, src, err)
+	}
+	if len(serviceAccount.Secrets) != 1 {
+		return fmt.Errorf("service account %v/%v on %v has %v secrets, expected 1", istioSystemNamespace, pilotServiceAccountName, src, len(serviceAccount.Secrets))
+	}
+	secretName := serviceAccount.Secrets[0].Name
+
+	w, err := srcKube.CoreV1().Secrets(istioSystemNamespace).Watch(metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("metadata.name=%v", secretName),
+	})
+	if err != nil {
+		return fmt.Errorf("watch secret %v/%v on %v: %v", istioSystemNamespace, secretName, src, err)
+	}
+	defer w.Stop()
+
+	for event := range w.ResultChan() {
+		if event.Type != watch.Modified {
+			continue
+		}
+
+		cmd.Printf("detected pilot SA token change on %v, re-pushing its remote secret\n", src)
+
+		desired, err := buildRemoteSecret(config, srcKube, src, istioSystemNamespace)
+		if err != nil {
+			return fmt.Errorf("rebuild remote secret for %v: %v", src, err)
+		}
+
+		for _, dst := range args.clusters {
+			if dst == src {
+				continue
+			}
+			if err := reconcileRemoteSecret(csm[dst], istioSystemNamespace, desired); err != nil {
+				return fmt.Errorf("reconcile remote secret for %v on %v: %v", src, dst, err)
+			}
+		}
+	}
+
+	return fmt.Errorf("watch on %v/%v (%v) closed", istioSystemNamespace, secretName, src)
+}
+
+// watchForTokenRotation starts one watch per cluster via watchClusterTokenRotation and
+// blocks until the first of them returns an error.
+func watchForTokenRotation(cmd *cobra.Command, args *Args, config *api.Config, csm map[string]kubernetes.Interface) error {
+	errCh := make(chan error, len(args.clusters))
+
+	for _, src := range args.clusters {
+		src := src
+		go func() {
+			errCh <- watchClusterTokenRotation(cmd, args, config, csm, src)
+		}()
+	}
+
+	return <-errCh
+}
+
 func GetJoinCommand(args *Args) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "join",
@@ -134,324 +702,427 @@ func GetJoinCommand(args *Args) *cobra.Command {
 				return err
 			}
 
-			if false {
-
-				// TODO - join to clusters first
+			if len(args.clusters) < 2 {
+				return fmt.Errorf("at least two clusters required - %v specified", len(args.clusters))
+			}
 
-				if len(args.clusters) != 2 {
-					cmd.Printf("only two clusters supported - %v clusters specified\n", len(args.clusters))
-					os.Exit(1)
+			csm := make(map[string]kubernetes.Interface, len(args.clusters))
+			for _, cluster := range args.clusters {
+				if _, ok := config.Contexts[cluster]; !ok {
+					return fmt.Errorf("cluster %q configuration not found", cluster)
 				}
 
-				csm := make(map[string]*kubernetes.Clientset, len(args.clusters))
+				if err := preflightAuthExpiry(args.kubeconfig, cluster); err != nil {
+					return fmt.Errorf("preflight for cluster %q: %v", cluster, err)
+				}
 
-				var notFound bool
-				for _, cluster := range args.clusters {
-					if _, ok := config.Contexts[cluster]; !ok {
-						cmd.Printf("cluster %q configuration not found\n", cluster)
-						notFound = true
-						continue
-					}
+				rest, err := BuildClientConfig(args.kubeconfig, cluster, args.authOverrides[cluster]).ClientConfig()
+				if err != nil {
+					return fmt.Errorf("could not build client for cluster %q: %v", cluster, err)
+				}
 
-					rest, err := BuildClientConfig(args.kubeconfig, cluster).ClientConfig()
-					if err != nil {
-						cmd.Printf("could not build client for cluster %q: %v\n", cluster, err)
-						notFound = true
-						continue
-					}
+				cs, err := kubernetes.NewForConfig(rest)
+				if err != nil {
+					return fmt.Errorf("could not create clientset for cluster %q: %v", cluster, err)
+				}
 
-					cs, err := kubernetes.NewForConfig(rest)
-					if err != nil {
-						cmd.Printf("could not create clientset for cluster %q: %v\n", cluster, err)
-						notFound = true
-						continue
-					}
+				if _, err = cs.CoreV1().Namespaces().Get(istioSystemNamespace, metav1.GetOptions{}); err != nil {
+					return fmt.Errorf("could not find %v namespace in cluster %q: %v", istioSystemNamespace, cluster, err)
+				}
 
-					if _, err = cs.CoreV1().Namespaces().Get("istio-system", metav1.GetOptions{}); err != nil {
-						// TODO - use errors.IsNotFound
-						cmd.Printf("could not find istio-system namespace in cluster %q: %v\n", cluster, err)
-						notFound = true
-						continue
-					}
-					cmd.Printf("found istio-system for cluster %v\n", cluster)
+				csm[cluster] = cs
+			}
 
-					csm[cluster] = cs
+			// The first cluster listed is treated as the mesh's root CA: every other
+			// cluster has its citadel disabled, its CA secrets synced from the primary,
+			// and its data plane restarted to pick up the shared root.
+			primary := args.clusters[0]
+			for _, dst := range args.clusters[1:] {
+				inSync, err := caInSync(csm[primary], csm[dst], istioSystemNamespace)
+				if err != nil {
+					return fmt.Errorf("check CA sync on %v: %v", dst, err)
 				}
-
-				if notFound {
-					os.Exit(1)
+				if inSync {
+					cmd.Printf("CA on %v already in sync with %v, skipping citadel bounce\n", dst, primary)
+					continue
 				}
 
-				// c0 := csm[Args.clusters[0]]
-				// c1 := csm[Args.clusters[1]]
-
-				// FLAT_NETWORK
-
-				// - CONTROL_PLANE
-				scale := func(replicas int) error {
-					args := []string{
-						"kubectl",
-						fmt.Sprintf("--context=%v", args.clusters[1]),
-						"scale",
-						"deployment",
-						"-n",
-						"istio-system",
-						"istio-citadel",
-						"--replicas",
-						strconv.Itoa(replicas),
-					}
-					if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
-						return fmt.Errorf("%v: %v", err, string(out))
-					}
-					return nil
+				cmd.Printf("disabling citadel on %v\n", dst)
+				if err := disableCitadel(csm[dst], istioSystemNamespace); err != nil {
+					return fmt.Errorf("disable citadel on %v: %v", dst, err)
 				}
-				wait := func() error {
-					args := []string{
-						"kubectl",
-						fmt.Sprintf("--context=%v", args.clusters[1]),
-						"rollout",
-						"status",
-						"deployment",
-						"-n",
-						"istio-system",
-						"istio-citadel",
-					}
-					if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
-						return fmt.Errorf("%v: %v", err, string(out))
-					}
-					return nil
+
+				cmd.Printf("syncing root CA from %v to %v\n", primary, dst)
+				if err := syncRootCA(csm[primary], csm[dst], istioSystemNamespace); err != nil {
+					return fmt.Errorf("sync root CA on %v: %v", dst, err)
 				}
 
-				if err := scale(0); err != nil {
-					log.Fatal(err)
+				namespaces, err := listNamespaces(csm[dst])
+				if err != nil {
+					return fmt.Errorf("list namespaces on %v: %v", dst, err)
 				}
-				if err := wait(); err != nil {
-					log.Fatal(err)
+
+				cmd.Printf("restarting data plane on %v\n", dst)
+				if err := restartDataPlane(csm[dst], namespaces); err != nil {
+					return fmt.Errorf("restart data plane on %v: %v", dst, err)
 				}
+			}
 
-				// $KUBECTL_DST -n istio-system delete secret istio-ca-secret || true
-				deleteSecret := func(namespace, secret string) error {
-					args := strings.Split(fmt.Sprintf("kubectl --context=%v -n %v delete secret %v", args.clusters[1], namespace, secret), " ")
-					fmt.Println(args)
-					if out, err := exec.Command(args[0], args[1:]...).CombinedOutput(); err != nil {
-						return fmt.Errorf("%v: %v", err, string(out))
-					} else {
-						fmt.Println(string(out))
-					}
+			if err := joinClusters(cmd, args, config, csm); err != nil {
+				return err
+			}
+
+			if args.watch {
+				cmd.Printf("watching pilot service account tokens for rotation (ctrl-c to stop)\n")
+				return watchForTokenRotation(cmd, args, config, csm)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&args.watch, "watch", false, "keep watching for pilot service account token rotation and re-push remote secrets as needed")
+
+	return cmd
+}
+
+// restorePreJoinCASecrets restores any CA secrets backed up by backupPreJoinCASecrets,
+// overwriting whatever syncRootCA put in their place, and removes the backups. It is a
+// no-op for any secret with no backup, so it's safe to call on a cluster that was never
+// the destination of a join.
+func restorePreJoinCASecrets(cmd *cobra.Command, cs kubernetes.Interface, namespace string, dryRun bool) error {
+	for _, secretName := range []string{caSecretName, cacertsSecretName} {
+		backupName := secretName + preJoinBackupSuffix
+
+		backup, err := cs.CoreV1().Secrets(namespace).Get(backupName, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("get backup secret %v/%v: %v", namespace, backupName, err)
+		}
+
+		if dryRun {
+			cmd.Printf("[dry-run] would restore secret %v/%v from %v and remove the backup\n", namespace, secretName, backupName)
+			continue
+		}
+
+		restored := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: namespace,
+			},
+			Data: backup.Data,
+			Type: backup.Type,
+		}
+		if _, err := cs.CoreV1().Secrets(namespace).Update(restored); errors.IsNotFound(err) {
+			if _, err := cs.CoreV1().Secrets(namespace).Create(restored); err != nil {
+				return fmt.Errorf("restore secret %v/%v: %v", namespace, secretName, err)
+			}
+		} else if err != nil {
+			return fmt.Errorf("restore secret %v/%v: %v", namespace, secretName, err)
+		}
 
-					return nil
+		if err := cs.CoreV1().Secrets(namespace).Delete(backupName, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			log.Printf("delete backup secret %v/%v: %v", namespace, backupName, err)
+		}
+	}
+
+	return nil
+}
+
+// unjoinCluster reverses a previous join on a single destination cluster: it removes
+// the remote-secrets that join created, restores any CA secret join overwrote, and
+// restarts the injected workloads so they pick the restored CA back up. It tolerates
+// missing secrets so a partially-joined or already-unjoined cluster doesn't abort the
+// whole `multi unjoin` run.
+func unjoinCluster(cmd *cobra.Command, cs kubernetes.Interface, namespace string, dryRun bool) error {
+	secrets, err := cs.CoreV1().Secrets(namespace).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%v=true", multiClusterLabel),
+	})
+	if err != nil {
+		return fmt.Errorf("list remote secrets: %v", err)
+	}
+
+	for _, secret := range secrets.Items {
+		if !strings.HasPrefix(secret.Name, remoteSecretPrefix) {
+			continue
+		}
+
+		if dryRun {
+			cmd.Printf("[dry-run] would delete secret %v/%v\n", namespace, secret.Name)
+			continue
+		}
+
+		if err := cs.CoreV1().Secrets(namespace).Delete(secret.Name, &metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+			cmd.Printf("could not delete secret %v/%v: %v\n", namespace, secret.Name, err)
+		}
+	}
+
+	if err := restorePreJoinCASecrets(cmd, cs, namespace, dryRun); err != nil {
+		cmd.Printf("could not restore pre-join CA secrets: %v\n", err)
+	}
+
+	if dryRun {
+		cmd.Printf("[dry-run] would restart injected workloads\n")
+		return nil
+	}
+
+	namespaces, err := listNamespaces(cs)
+	if err != nil {
+		return fmt.Errorf("list namespaces: %v", err)
+	}
+	return restartDataPlane(cs, namespaces)
+}
+
+// GetUnjoinCommand returns the `multi unjoin` subcommand, which reverses a previous
+// `multi join` on each of --clusters.
+func GetUnjoinCommand(args *Args) *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "unjoin",
+		Short: "Remove clusters from a mesh, reversing a previous join",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := args.config.ConfigAccess().GetStartingConfig()
+			if err != nil {
+				return err
+			}
+
+			var failures []string
+			for _, cluster := range args.clusters {
+				if _, ok := config.Contexts[cluster]; !ok {
+					cmd.Printf("cluster %q configuration not found, skipping\n", cluster)
+					failures = append(failures, cluster)
+					continue
 				}
-				// remove existing self-signed and externally provided certs
-				if err := deleteSecret("istio-system", "istio-ca-secret"); err != nil {
-					log.Print(err)
+
+				rest, err := BuildClientConfig(args.kubeconfig, cluster, args.authOverrides[cluster]).ClientConfig()
+				if err != nil {
+					cmd.Printf("could not build client for cluster %q: %v\n", cluster, err)
+					failures = append(failures, cluster)
+					continue
 				}
 
-				cargs := strings.Split(fmt.Sprintf("kubectl --context=%v get namespace -o jsonpath={.items[*].metadata.name}", args.clusters[1]), " ")
-				out, err := exec.Command(cargs[0], cargs[1:]...).CombinedOutput()
+				cs, err := kubernetes.NewForConfig(rest)
 				if err != nil {
-					return fmt.Errorf("%v: %v", err, string(out))
+					cmd.Printf("could not create clientset for cluster %q: %v\n", cluster, err)
+					failures = append(failures, cluster)
+					continue
 				}
 
-				fmt.Println("NS", string(out))
+				cmd.Printf("unjoining %v\n", cluster)
+				if err := unjoinCluster(cmd, cs, istioSystemNamespace, dryRun); err != nil {
+					cmd.Printf("could not unjoin cluster %q: %v\n", cluster, err)
+					failures = append(failures, cluster)
+				}
+			}
 
-				// TODO - this should delete *all* Istio secrets
-				namespaces := strings.Split(string(out), " ")
+			if len(failures) > 0 {
+				return fmt.Errorf("unjoin failed for cluster(s): %v", strings.Join(failures, ", "))
+			}
+			return nil
+		},
+	}
 
-				for _, namespace := range namespaces {
-					args := strings.Split(fmt.Sprintf("kubectl --context=%v -n %v delete secret istio.default", namespace, args.clusters[1]), " ")
-					exec.Command(args[0], args[1:]...).CombinedOutput()
-				}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "print the objects that would be removed without making any changes")
 
-				fmt.Println("copy secrets to joined cluster")
-				// TODO source cluster may have self-signed or plugged cert. We need to copy one or the other (but not both) to joined cluster.
-				for _, secret := range []string{"istio-ca-secret", "cacerts"} {
-					cargs = strings.Split(fmt.Sprintf("kubectl --context=%v -n istio-system get secret %v -o yaml --export", args.clusters[0], secret), " ")
-					out, err = exec.Command(cargs[0], cargs[1:]...).CombinedOutput()
-					if err != nil {
-						log.Printf("%v: %v\n", err, string(out))
-						continue
-					}
+	return cmd
+}
 
-					t, err := ioutil.TempFile("", "")
-					if err != nil {
-						log.Fatal(err)
-					}
-					_, err = t.Write(out)
-					if err != nil {
-						log.Fatal(err)
-					}
-					t.Close()
+// jwtClaims is the handful of JWT claims statusTokenExpiry needs to report a service
+// account token's expiry.
+type jwtClaims struct {
+	Exp int64 `json:"exp"`
+}
 
-					fmt.Println("saved to ", t.Name())
-					cargs = strings.Split(fmt.Sprintf("kubectl --context=%v -n istio-system apply -f %v --validate=false", args.clusters[1], t.Name()), " ")
-					out, err = exec.Command(cargs[0], cargs[1:]...).CombinedOutput()
-					if err != nil {
-						return fmt.Errorf("%v: %v", err, string(out))
-					}
-				}
+// statusTokenExpiry decodes a JWT's claims and returns when it expires. It returns the
+// zero Time for opaque tokens or ones with no "exp" claim.
+func statusTokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return time.Time{}
+	}
 
-				if err := scale(1); err != nil {
-					log.Fatal(err)
-				}
-				if err := wait(); err != nil {
-					log.Fatal(err)
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return time.Time{}
+	}
+
+	var claims jwtClaims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Exp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(claims.Exp, 0)
+}
+
+// remoteClientsetFromSecret builds a clientset for the remote cluster described by a
+// istio-mc-<src> secret's embedded kubeconfig, along with the token it embeds so the
+// caller can report its expiry.
+func remoteClientsetFromSecret(secret *v1.Secret) (kubernetes.Interface, string, error) {
+	for _, kubeconfig := range secret.Data {
+		rawConfig, err := clientcmd.Load(kubeconfig)
+		if err != nil {
+			return nil, "", fmt.Errorf("parse embedded kubeconfig: %v", err)
+		}
+
+		rest, err := clientcmd.NewDefaultClientConfig(*rawConfig, &clientcmd.ConfigOverrides{}).ClientConfig()
+		if err != nil {
+			return nil, "", fmt.Errorf("build client from embedded kubeconfig: %v", err)
+		}
+
+		cs, err := kubernetes.NewForConfig(rest)
+		if err != nil {
+			return nil, "", fmt.Errorf("create clientset from embedded kubeconfig: %v", err)
+		}
+
+		authInfo := rawConfig.AuthInfos[rawConfig.Contexts[rawConfig.CurrentContext].AuthInfo]
+		return cs, authInfo.Token, nil
+	}
+
+	return nil, "", fmt.Errorf("secret %v has no embedded kubeconfig", secret.Name)
+}
+
+// pilotVersion inspects istio-pilot's deployment on a remote cluster and returns the
+// tag of its first container's image, or "unknown" if the deployment can't be read.
+func pilotVersion(cs kubernetes.Interface, namespace string) string {
+	dep, err := cs.AppsV1().Deployments(namespace).Get("istio-pilot", metav1.GetOptions{})
+	if err != nil || len(dep.Spec.Template.Spec.Containers) == 0 {
+		return "unknown"
+	}
+
+	image := dep.Spec.Template.Spec.Containers[0].Image
+	if i := strings.LastIndex(image, ":"); i >= 0 {
+		return image[i+1:]
+	}
+	return image
+}
+
+// statusRow is one SRC -> DST remote-secret's reported health.
+type statusRow struct {
+	src          string
+	dst          string
+	secretAge    time.Duration
+	tokenExpiry  time.Time
+	reachable    bool
+	pilotVersion string
+}
+
+// GetStatusCommand returns the `multi status` subcommand, which reports whether every
+// declared cluster's remote-secrets still describe a live, reachable control plane, and
+// flags any SRC -> DST pair that's missing one entirely.
+func GetStatusCommand(args *Args) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Report the health of a multi-cluster mesh",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			config, err := args.config.ConfigAccess().GetStartingConfig()
+			if err != nil {
+				return err
+			}
+
+			csm := make(map[string]kubernetes.Interface, len(args.clusters))
+			for _, cluster := range args.clusters {
+				if _, ok := config.Contexts[cluster]; !ok {
+					return fmt.Errorf("cluster %q configuration not found", cluster)
 				}
 
-				patch := fmt.Sprintf(`{"spec":{"template":{"metadata":{"annotations":{"date":"%v"}}}}}`, time.Now().UTC().Format(time.RFC3339))
-
-				for _, namespace := range namespaces {
-					switch namespace {
-					case "kube-system", "kube-public":
-					default:
-						cargs := strings.Split(fmt.Sprintf("kubectl --context=%v -n %v get deployment -o=name", args.clusters[1], namespace), " ")
-						out, err := exec.Command(cargs[0], cargs[1:]...).CombinedOutput()
-						if err != nil {
-							log.Fatalf("%v: %v", err, string(out))
-						}
-						for _, deployment := range strings.Split(string(out), "\n") {
-							if deployment == "" {
-								continue
-							}
-							cargs = strings.Split(fmt.Sprintf("kubectl --context=%v -n %v patch %v -p %s", args.clusters[1], namespace, deployment, patch), " ")
-							out, err = exec.Command(cargs[0], cargs[1:]...).CombinedOutput()
-							if err != nil {
-								log.Fatalf("%v: %v", err, string(out))
-							}
-						}
-					}
+				rest, err := BuildClientConfig(args.kubeconfig, cluster, args.authOverrides[cluster]).ClientConfig()
+				if err != nil {
+					return fmt.Errorf("could not build client for cluster %q: %v", cluster, err)
 				}
 
-				for _, namespace := range namespaces {
-					switch namespace {
-					case "kube-system", "kube-public":
-					default:
-						cargs := strings.Split(fmt.Sprintf("kubectl --context=%v -n %v get deployment -o=name", args.clusters[1], namespace), " ")
-						out, err := exec.Command(cargs[0], cargs[1:]...).CombinedOutput()
-						if err != nil {
-							log.Fatalf("%v: %v", err, string(out))
-						}
-						for _, deployment := range strings.Split(string(out), "\n") {
-							if deployment == "" {
-								continue
-							}
-							cargs = strings.Split(fmt.Sprintf("kubectl --context=%v -n %v rollout status %v", args.clusters[1], namespace, deployment), " ")
-							out, err = exec.Command(cargs[0], cargs[1:]...).CombinedOutput()
-							if err != nil {
-								log.Fatalf("%v: %v", err, string(out))
-							}
-							fmt.Println(string(out))
-						}
-					}
+				cs, err := kubernetes.NewForConfig(rest)
+				if err != nil {
+					return fmt.Errorf("could not create clientset for cluster %q: %v", cluster, err)
 				}
+
+				csm[cluster] = cs
 			}
-			// create k8s secret with c0 pilot SA kubeconfig, label, and copy to c1
-			// create k8s secret with c1 pilot SA kubeconfig, label, and copy to c0
 
-			// TODO - multiple kubeconfig context may point to the same cluster.
+			var rows []statusRow
+			found := make(map[string]map[string]bool, len(args.clusters))
 			for _, dst := range args.clusters {
-				dstRest, err := BuildClientConfig(args.kubeconfig, dst).ClientConfig()
-				if err != nil {
-					log.Fatal(err)
-				}
+				found[dst] = make(map[string]bool, len(args.clusters))
 
-				dstKube, err := kubernetes.NewForConfig(dstRest)
+				secrets, err := csm[dst].CoreV1().Secrets(istioSystemNamespace).List(metav1.ListOptions{
+					LabelSelector: fmt.Sprintf("%v=true", multiClusterLabel),
+				})
 				if err != nil {
-					log.Fatal(err)
+					cmd.Printf("could not list remote secrets on %v: %v\n", dst, err)
+					continue
 				}
 
-				for _, src := range args.clusters {
-					// skip self
-					if src == dst {
+				for _, secret := range secrets.Items {
+					if !strings.HasPrefix(secret.Name, remoteSecretPrefix) {
 						continue
 					}
-					fmt.Printf("joining %v to %v\n", src, dst)
-
-					// local CLUSTER_NAME=$($KUBECTL_SLAVE config view -o jsonpath="{.contexts[?(@.name == \"${KUBECONTEXT_SLAVE}\")].context.cluster}")
-					clusterName := config.Contexts[dst].Cluster
-
-					// local SERVER=$($KUBECTL_SLAVE config view -o jsonpath="{.clusters[?(@.name == \"${CLUSTER_NAME}\")].cluster.server}")
-					server := config.Clusters[clusterName].Server
+					src := strings.TrimPrefix(secret.Name, remoteSecretPrefix)
+					found[dst][src] = true
 
-					// local NAMESPACE=istio-system
-					namespace := "istio-system"
-
-					// local SERVICE_ACCOUNT=istio-pilot-service-account
-					serviceAccountName := "istio-pilot-service-account"
-
-					srcRest, err := BuildClientConfig(args.kubeconfig, src).ClientConfig()
-					if err != nil {
-						log.Fatal(err)
+					row := statusRow{
+						src:       src,
+						dst:       dst,
+						secretAge: time.Since(secret.CreationTimestamp.Time),
 					}
 
-					srcKube, err := kubernetes.NewForConfig(srcRest)
+					remoteCS, token, err := remoteClientsetFromSecret(&secret)
 					if err != nil {
-						log.Fatal(err)
+						cmd.Printf("could not read remote secret %v/%v: %v\n", dst, secret.Name, err)
+						rows = append(rows, row)
+						continue
 					}
+					row.tokenExpiry = statusTokenExpiry(token)
 
-					// local SECRET_NAME=$($KUBECTL_SLAVE get sa ${SERVICE_ACCOUNT} -n ${NAMESPACE} -o jsonpath="{.secrets[].name}")
-					serviceAccount, err := srcKube.CoreV1().ServiceAccounts(namespace).Get(serviceAccountName, metav1.GetOptions{})
-					if err != nil {
-						log.Fatal(err)
-					}
-					if len(serviceAccount.Secrets) != 1 {
-						log.Fatal(err)
+					if _, err := remoteCS.CoreV1().Namespaces().Get(istioSystemNamespace, metav1.GetOptions{}); err == nil {
+						row.reachable = true
+						row.pilotVersion = pilotVersion(remoteCS, istioSystemNamespace)
 					}
-					secretName := serviceAccount.Secrets[0].Name
 
-					// local CA_DATA=$($KUBECTL_SLAVE get secret ${SECRET_NAME} -n ${NAMESPACE} -o jsonpath="{.data['ca\.crt']}")
-					pilotSecret, err := srcKube.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
-					if err != nil {
-						log.Fatal(err)
-					}
-					caData, ok := pilotSecret.Data["ca.crt"]
-					if !ok {
-						log.Fatalf("%v is missing ca.crt", secretName)
-					}
+					rows = append(rows, row)
+				}
+			}
 
-					// local TOKEN=$($KUBECTL_SLAVE get secret ${SECRET_NAME} -n ${NAMESPACE} -o jsonpath="{.data['token']}" | base64 --decode)
-					token, ok := pilotSecret.Data["token"]
-					if !ok {
-						log.Fatalf("%v is missing token", secretName)
-					}
+			out := tabwriter.NewWriter(os.Stdout, 1, 8, 1, '\t', 0)
+			fmt.Fprintf(out, "SRC\tDST\tSECRET-AGE\tTOKEN-EXPIRY\tREACHABLE\tPILOT-VERSION\n")
+			for _, row := range rows {
+				expiry := "n/a"
+				if !row.tokenExpiry.IsZero() {
+					expiry = row.tokenExpiry.UTC().Format(time.RFC3339)
+				}
+				fmt.Fprintf(out, "%v\t%v\t%v\t%v\t%v\t%v\n",
+					row.src, row.dst, row.secretAge.Round(time.Second), expiry, row.reachable, row.pilotVersion)
+			}
+			if err := out.Flush(); err != nil {
+				return err
+			}
 
-					sc := api.NewConfig()
-					sc.Kind = "Config"
-					sc.APIVersion = "v1"
-					sc.Clusters[clusterName] = &api.Cluster{
-						CertificateAuthorityData: caData,
-						Server:                   server,
-					}
-					sc.Contexts[clusterName] = &api.Context{
-						Cluster:  clusterName,
-						AuthInfo: clusterName,
+			var issues []string
+			for _, dst := range args.clusters {
+				for _, src := range args.clusters {
+					if src == dst {
+						continue
 					}
-					sc.CurrentContext = clusterName
-					sc.AuthInfos[clusterName] = &api.AuthInfo{
-						Token: string(token),
+					if !found[dst][src] {
+						issues = append(issues, fmt.Sprintf("%v -> %v: missing remote secret", src, dst))
 					}
+				}
+			}
+			for _, row := range rows {
+				switch {
+				case !row.reachable:
+					issues = append(issues, fmt.Sprintf("%v -> %v: unreachable", row.src, row.dst))
+				case !row.tokenExpiry.IsZero() && time.Now().After(row.tokenExpiry):
+					issues = append(issues, fmt.Sprintf("%v -> %v: token expired at %v", row.src, row.dst, row.tokenExpiry.UTC().Format(time.RFC3339)))
+				}
+			}
 
-					srcSecret := &v1.Secret{
-						ObjectMeta: metav1.ObjectMeta{
-							Name:      fmt.Sprintf("istio-mc-%v", src),
-							Namespace: namespace,
-							Labels: map[string]string{
-								"istio/multiCluster": "true",
-							},
-						},
-					}
-
-					if result, err := dstKube.CoreV1().Secrets(namespace).Create(srcSecret); errors.IsAlreadyExists(err) {
-						fmt.Println("secret exists:", result)
-
-						patch, err := json.Marshal(srcSecret)
-						if err != nil {
-							log.Fatal(err)
-						}
-
-						res, err := dstKube.CoreV1().Secrets(namespace).Patch(srcSecret.Name, types.StrategicMergePatchType, patch)
-						fmt.Println("PATCH: err: ", err)
-						fmt.Println("PATCH: result: ", res)
-					}
+			if len(issues) > 0 {
+				cmd.Printf("\nmesh issues:\n")
+				for _, issue := range issues {
+					cmd.Printf("  %v\n", issue)
 				}
 			}
 
@@ -464,24 +1135,60 @@ func GetJoinCommand(args *Args) *cobra.Command {
 
 func GetCommand() *cobra.Command {
 	var args Args
+	var authProviders map[string]string
+	var authProviderConfig []string
 
 	cmd := &cobra.Command{
 		Use:   "multi",
 		Short: "Setup a multi-cluster mesh",
 		Args:  cobra.ExactArgs(0),
 		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
-			args.config = BuildClientConfig(args.kubeconfig, args.context)
+			args.authOverrides = make(map[string]AuthOverrides, len(authProviders))
+			for cluster, provider := range authProviders {
+				switch provider {
+				case authProviderExec, authProviderGCP, authProviderOIDC, authProviderAzure:
+				default:
+					return fmt.Errorf("unknown --auth-provider %q for cluster %q: must be one of exec, gcp, oidc, azure",
+						provider, cluster)
+				}
+				args.authOverrides[cluster] = AuthOverrides{Provider: provider}
+			}
+
+			for _, entry := range authProviderConfig {
+				parts := strings.SplitN(entry, "=", 3)
+				if len(parts) != 3 {
+					return fmt.Errorf("invalid --auth-provider-config %q: must be cluster=key=value", entry)
+				}
+				cluster, key, value := parts[0], parts[1], parts[2]
+
+				override := args.authOverrides[cluster]
+				if override.Config == nil {
+					override.Config = make(map[string]string)
+				}
+				override.Config[key] = value
+				args.authOverrides[cluster] = override
+			}
+
+			args.config = BuildClientConfig(args.kubeconfig, args.context, args.authOverrides[args.context])
 			return nil
 		},
 	}
 
 	cmd.AddCommand(GetListCommand(&args))
 	cmd.AddCommand(GetJoinCommand(&args))
+	cmd.AddCommand(GetUnjoinCommand(&args))
+	cmd.AddCommand(GetStatusCommand(&args))
 
 	cmd.PersistentFlags().StringVar(&args.kubeconfig, "kubeconfig", "", "kubeconfig file")
 	cmd.PersistentFlags().StringVar(&args.context, "context", "", "current context")
 	cmd.PersistentFlags().StringSliceVar(&args.clusters, "clusters", nil, "cluster contexts")
 	cmd.PersistentFlags().StringVarP(&args.namespace, "namespace", "n", "", "namespace")
+	cmd.PersistentFlags().StringToStringVar(&authProviders, "auth-provider", nil,
+		"cluster=provider pairs (exec|gcp|oidc|azure); gcp, oidc and azure force that cluster's kubeconfig auth provider "+
+			"when the context doesn't already have one configured, exec leaves the kubeconfig's auth unchanged")
+	cmd.PersistentFlags().StringArrayVar(&authProviderConfig, "auth-provider-config", nil,
+		"cluster=key=value entries (repeatable) supplying the Config data --auth-provider=oidc/azure needs, "+
+			"e.g. cluster=idp-issuer-url=https://...")
 
 	return cmd
 }
@@ -0,0 +1,242 @@
+// Copyright 2019 Istio Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multi
+
+import (
+	"sort"
+	"testing"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	kubetesting "k8s.io/client-go/testing"
+)
+
+func int32Ptr(i int32) *int32 { return &i }
+
+// fakeClientsetWithScale returns a fake clientset whose deployments/scale subresource is
+// backed by each deployment's own Spec.Replicas/Status.Replicas, since
+// fake.NewSimpleClientset alone doesn't wire up GetScale/UpdateScale for plain
+// *appsv1.Deployment objects. The reactors go through the object tracker directly,
+// since calling back into the clientset from a reactor would deadlock on its lock.
+func fakeClientsetWithScale(objects ...runtime.Object) *fake.Clientset {
+	cs := fake.NewSimpleClientset(objects...)
+	deploymentsResource := appsv1.SchemeGroupVersion.WithResource("deployments")
+
+	cs.PrependReactor("get", "deployments", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		getAction := action.(kubetesting.GetAction)
+		obj, err := cs.Tracker().Get(deploymentsResource, getAction.GetNamespace(), getAction.GetName())
+		if err != nil {
+			return true, nil, err
+		}
+		return true, deploymentToScale(obj.(*appsv1.Deployment)), nil
+	})
+
+	cs.PrependReactor("update", "deployments", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "scale" {
+			return false, nil, nil
+		}
+		updateAction := action.(kubetesting.UpdateAction)
+		scale := updateAction.GetObject().(*autoscalingv1.Scale)
+
+		obj, err := cs.Tracker().Get(deploymentsResource, scale.Namespace, scale.Name)
+		if err != nil {
+			return true, nil, err
+		}
+		dep := obj.(*appsv1.Deployment)
+		dep.Spec.Replicas = &scale.Spec.Replicas
+		if err := cs.Tracker().Update(deploymentsResource, dep, scale.Namespace); err != nil {
+			return true, nil, err
+		}
+		return true, scale, nil
+	})
+
+	return cs
+}
+
+func deploymentToScale(dep *appsv1.Deployment) *autoscalingv1.Scale {
+	replicas := int32(1)
+	if dep.Spec.Replicas != nil {
+		replicas = *dep.Spec.Replicas
+	}
+	return &autoscalingv1.Scale{
+		ObjectMeta: metav1.ObjectMeta{Name: dep.Name, Namespace: dep.Namespace},
+		Spec:       autoscalingv1.ScaleSpec{Replicas: replicas},
+		Status:     autoscalingv1.ScaleStatus{Replicas: dep.Status.Replicas},
+	}
+}
+
+// rolledOutDeployment returns a deployment whose Status already reflects replicas ready,
+// so scaleDeployment and waitForRollout return without polling or watching.
+func rolledOutDeployment(namespace, name string, replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       appsv1.DeploymentSpec{Replicas: int32Ptr(replicas)},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 1,
+			Replicas:           replicas,
+			UpdatedReplicas:    replicas,
+			AvailableReplicas:  replicas,
+			ReadyReplicas:      replicas,
+		},
+	}
+}
+
+func TestListNamespaces(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: istioSystemNamespace}},
+	)
+
+	names, err := listNamespaces(cs)
+	if err != nil {
+		t.Fatalf("listNamespaces: %v", err)
+	}
+
+	sort.Strings(names)
+	want := []string{"default", istioSystemNamespace}
+	if len(names) != len(want) || names[0] != want[0] || names[1] != want[1] {
+		t.Fatalf("listNamespaces = %v, want %v", names, want)
+	}
+}
+
+func TestScaleDeployment(t *testing.T) {
+	cs := fakeClientsetWithScale(rolledOutDeployment(istioSystemNamespace, citadelDeploymentName, 1))
+
+	if err := scaleDeployment(cs, istioSystemNamespace, citadelDeploymentName, 1); err != nil {
+		t.Fatalf("scaleDeployment: %v", err)
+	}
+}
+
+func TestDisableCitadel(t *testing.T) {
+	cs := fakeClientsetWithScale(rolledOutDeployment(istioSystemNamespace, citadelDeploymentName, 0))
+
+	if err := disableCitadel(cs, istioSystemNamespace); err != nil {
+		t.Fatalf("disableCitadel: %v", err)
+	}
+
+	dep, err := cs.AppsV1().Deployments(istioSystemNamespace).Get(citadelDeploymentName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+	if *dep.Spec.Replicas != 0 {
+		t.Fatalf("citadel replicas = %v, want 0", *dep.Spec.Replicas)
+	}
+}
+
+func TestCAInSync(t *testing.T) {
+	caSecret := func(data string) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: caSecretName, Namespace: istioSystemNamespace},
+			Data:       map[string][]byte{"ca-cert.pem": []byte(data)},
+		}
+	}
+
+	src := fake.NewSimpleClientset(caSecret("root"))
+
+	dstMatching := fake.NewSimpleClientset(caSecret("root"))
+	inSync, err := caInSync(src, dstMatching, istioSystemNamespace)
+	if err != nil {
+		t.Fatalf("caInSync (matching): %v", err)
+	}
+	if !inSync {
+		t.Fatal("caInSync (matching) = false, want true")
+	}
+
+	dstStale := fake.NewSimpleClientset(caSecret("stale"))
+	inSync, err = caInSync(src, dstStale, istioSystemNamespace)
+	if err != nil {
+		t.Fatalf("caInSync (stale): %v", err)
+	}
+	if inSync {
+		t.Fatal("caInSync (stale) = true, want false")
+	}
+
+	dstMissing := fake.NewSimpleClientset()
+	inSync, err = caInSync(src, dstMissing, istioSystemNamespace)
+	if err != nil {
+		t.Fatalf("caInSync (missing): %v", err)
+	}
+	if inSync {
+		t.Fatal("caInSync (missing) = true, want false")
+	}
+}
+
+func TestSyncRootCA(t *testing.T) {
+	srcSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: caSecretName, Namespace: istioSystemNamespace},
+		Data:       map[string][]byte{"ca-cert.pem": []byte("root")},
+	}
+	src := fake.NewSimpleClientset(srcSecret)
+
+	dst := fakeClientsetWithScale(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: istioSystemNamespace}},
+		rolledOutDeployment(istioSystemNamespace, citadelDeploymentName, 1),
+	)
+
+	if err := syncRootCA(src, dst, istioSystemNamespace); err != nil {
+		t.Fatalf("syncRootCA: %v", err)
+	}
+
+	got, err := dst.CoreV1().Secrets(istioSystemNamespace).Get(caSecretName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get synced secret: %v", err)
+	}
+	if string(got.Data["ca-cert.pem"]) != "root" {
+		t.Fatalf("synced secret data = %q, want %q", got.Data["ca-cert.pem"], "root")
+	}
+}
+
+func TestWaitForRollout(t *testing.T) {
+	cs := fake.NewSimpleClientset(rolledOutDeployment("default", "istio-pilot", 1))
+
+	if err := waitForRollout(cs, "default", "istio-pilot"); err != nil {
+		t.Fatalf("waitForRollout: %v", err)
+	}
+}
+
+func TestRestartDataPlane(t *testing.T) {
+	cs := fake.NewSimpleClientset(
+		rolledOutDeployment("app-ns", "frontend", 1),
+		rolledOutDeployment("kube-system", "coredns", 1),
+	)
+
+	if err := restartDataPlane(cs, []string{"app-ns", "kube-system"}); err != nil {
+		t.Fatalf("restartDataPlane: %v", err)
+	}
+
+	dep, err := cs.AppsV1().Deployments("app-ns").Get("frontend", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get patched deployment: %v", err)
+	}
+	if dep.Spec.Template.ObjectMeta.Annotations["date"] == "" {
+		t.Fatal("frontend deployment was not annotated with a restart date")
+	}
+
+	dep, err = cs.AppsV1().Deployments("kube-system").Get("coredns", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get kube-system deployment: %v", err)
+	}
+	if dep.Spec.Template.ObjectMeta.Annotations["date"] != "" {
+		t.Fatal("kube-system deployment should have been skipped, but was annotated")
+	}
+}